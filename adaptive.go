@@ -0,0 +1,145 @@
+package breakermux
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+)
+
+// AdaptiveSettings configures EWMA-based adaptive tripping for a mux. It
+// replaces gobreaker's default "N consecutive failures" ReadyToTrip with one
+// built from an exponentially-weighted moving average of error-rate and
+// latency, tracked independently per key.
+//
+// Tau is the time constant used to weight the error-rate and latency EWMAs:
+// on each request, the weight given to the new sample is
+// alpha = 1 - exp(-dt/Tau), where dt is the time since the entry's previous
+// update. If Tau is less than or equal to 0, every sample fully replaces the
+// previous average.
+//
+// BaselineTau is the (typically much longer) time constant for the latency
+// baseline EWMA, which is only updated on successful calls, so a sustained
+// slowdown doesn't erode the baseline it's being measured against.
+//
+// MinRequests is the minimum number of requests an entry must have seen
+// before either threshold below is consulted.
+//
+// ErrThreshold trips the 'breaker once the error-rate EWMA (0-1) exceeds it.
+// A value of less than or equal to 0 disables the error-rate check.
+//
+// LatencyThreshold trips the 'breaker once the latency EWMA exceeds
+// LatencyThreshold times the baseline latency EWMA. A value of less than or
+// equal to 0 disables the latency check.
+type AdaptiveSettings struct {
+	Tau              time.Duration
+	BaselineTau      time.Duration
+	MinRequests      uint32
+	ErrThreshold     float64
+	LatencyThreshold float64
+}
+
+// adaptiveReadyToTrip builds the gobreaker ReadyToTrip for a single key's
+// 'breaker, consulting cba's EWMAs instead of counts.ConsecutiveFailures.
+func adaptiveReadyToTrip(as *AdaptiveSettings, cba *cache) func(gobreaker.Counts) bool {
+	return func(counts gobreaker.Counts) bool {
+		// cba.ewmaCount, not counts.Requests: gobreaker resets Requests every
+		// Interval and on each new generation (e.g. a half-open probe), so it
+		// can read zero right when a key most needs its MinRequests warm-up
+		// respected. ewmaCount is folded into the same EWMAs MinRequests is
+		// gating and persists for the life of the key's entry.
+		if cba.ewmaCount.Load() < as.MinRequests {
+			return false
+		}
+
+		if as.ErrThreshold > 0 && loadFloat(&cba.errEWMA) > as.ErrThreshold {
+			return true
+		}
+
+		if as.LatencyThreshold > 0 {
+			if baseline := loadFloat(&cba.baseEWMA); baseline > 0 {
+				if loadFloat(&cba.latEWMA) > as.LatencyThreshold*baseline {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+}
+
+// updateAdaptive folds the outcome of a single request into this entry's
+// error-rate and latency EWMAs. Successful calls also update the long-tau
+// baseline latency EWMA that the latency EWMA is compared against.
+func (c *cache) updateAdaptive(as *AdaptiveSettings, success bool, latency time.Duration) {
+	now := time.Now().UnixMicro()
+	prev := c.ewmaUpdated.Swap(now)
+	c.ewmaCount.Add(1)
+
+	var dt time.Duration
+	if prev > 0 {
+		dt = time.Duration(now-prev) * time.Microsecond
+	}
+
+	errSample := 0.0
+	if !success {
+		errSample = 1.0
+	}
+	latMs := float64(latency.Microseconds()) / 1000.0
+
+	alpha := ewmaAlpha(dt, as.Tau)
+	storeFloat(&c.errEWMA, ewmaNext(loadFloat(&c.errEWMA), errSample, alpha))
+	storeFloat(&c.latEWMA, ewmaNext(loadFloat(&c.latEWMA), latMs, alpha))
+
+	if success {
+		baseAlpha := ewmaAlpha(dt, as.BaselineTau)
+		storeFloat(&c.baseEWMA, ewmaNext(loadFloat(&c.baseEWMA), latMs, baseAlpha))
+	}
+}
+
+// ewmaAlpha computes the weight given to a new sample arriving dt after the
+// previous one, for an EWMA with time constant tau. The first sample (dt<=0,
+// i.e. nothing has been recorded yet) always gets a weight of 1, seeding the
+// average directly rather than blending with a meaningless zero value. A
+// sample landing in the same microsecond as the previous one also has dt==0,
+// and so likewise replaces the average outright.
+func ewmaAlpha(dt, tau time.Duration) float64 {
+	if tau <= 0 || dt <= 0 {
+		return 1
+	}
+	return 1 - math.Exp(-float64(dt)/float64(tau))
+}
+
+// ewmaNext blends sample into prev with the given weight.
+func ewmaNext(prev, sample, alpha float64) float64 {
+	return prev + alpha*(sample-prev)
+}
+
+// loadFloat reads a float64 stored via storeFloat.
+func loadFloat(a *atomic.Uint64) float64 {
+	return math.Float64frombits(a.Load())
+}
+
+// storeFloat stores v for later retrieval via loadFloat.
+func storeFloat(a *atomic.Uint64, v float64) {
+	a.Store(math.Float64bits(v))
+}
+
+// AdaptiveStats reports the current error-rate EWMA (0-1), latency EWMA, and
+// baseline latency EWMA (both in milliseconds) for key's 'breaker. ok is
+// false if key has no 'breaker yet, or this mux wasn't configured with
+// Settings[T].Adaptive.
+func (c *CircuitBreakerMux[T]) AdaptiveStats(key string) (errRate, latencyMs, baselineMs float64, ok bool) {
+	if c.adaptive == nil {
+		return 0, 0, 0, false
+	}
+
+	cbaAny, loaded := c.breakers.Load(key)
+	if !loaded {
+		return 0, 0, 0, false
+	}
+
+	cba := cbaAny.(*cache)
+	return loadFloat(&cba.errEWMA), loadFloat(&cba.latEWMA), loadFloat(&cba.baseEWMA), true
+}
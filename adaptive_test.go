@@ -0,0 +1,98 @@
+package breakermux
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/sony/gobreaker/v2"
+)
+
+func TestAdaptiveErrorRate(t *testing.T) {
+	Convey("When a mux is configured with Adaptive error-rate tripping, a burst of failures trips it before reaching the default consecutive-failure count.", t, func() {
+		var state = gobreaker.StateClosed
+
+		st := Settings[string]{}
+		st.Timeout = time.Hour
+		st.OnStateChange = func(name string, from, to gobreaker.State) {
+			state = to
+		}
+		st.Adaptive = &AdaptiveSettings{
+			MinRequests:  3,
+			ErrThreshold: 0.5,
+		}
+		st.ExecClosure = func(input string) func() (string, error) {
+			return func() (string, error) {
+				if input == "yes" {
+					return "yes", nil
+				}
+				return "", fmt.Errorf("boom")
+			}
+		}
+
+		cbm := NewMux(st)
+		defer cbm.Close()
+
+		for range 10 {
+			cbm.Get("flaky")
+			if state == gobreaker.StateOpen {
+				break
+			}
+		}
+
+		So(state, ShouldEqual, gobreaker.StateOpen)
+
+		errRate, _, _, ok := cbm.AdaptiveStats("flaky")
+		So(ok, ShouldBeTrue)
+		So(errRate, ShouldBeGreaterThan, 0.5)
+	})
+}
+
+func TestAdaptiveReadyToTripGatesOnEwmaCountNotCounts(t *testing.T) {
+	Convey("Given a cache whose EWMAs have seen enough requests to trip, but a gobreaker.Counts reporting none (e.g. just after a new generation)", t, func() {
+		cba := newCache()
+		as := &AdaptiveSettings{MinRequests: 3, ErrThreshold: 0.5}
+
+		for range 3 {
+			cba.updateAdaptive(as, false, time.Millisecond)
+		}
+
+		readyToTrip := adaptiveReadyToTrip(as, cba)
+
+		Convey("ReadyToTrip still fires off of the cache's own count, ignoring Counts.Requests", func() {
+			So(readyToTrip(gobreaker.Counts{}), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a cache that hasn't yet seen MinRequests", t, func() {
+		cba := newCache()
+		as := &AdaptiveSettings{MinRequests: 3, ErrThreshold: 0.5}
+
+		cba.updateAdaptive(as, false, time.Millisecond)
+		cba.updateAdaptive(as, false, time.Millisecond)
+
+		readyToTrip := adaptiveReadyToTrip(as, cba)
+
+		Convey("ReadyToTrip holds off even though a stale Counts reports plenty of requests", func() {
+			So(readyToTrip(gobreaker.Counts{Requests: 100}), ShouldBeFalse)
+		})
+	})
+}
+
+func TestAdaptiveStatsDisabled(t *testing.T) {
+	Convey("When a mux has no Adaptive settings, AdaptiveStats reports ok=false.", t, func() {
+		st := Settings[string]{}
+		st.ExecClosure = func(input string) func() (string, error) {
+			return func() (string, error) { return "ok", nil }
+		}
+
+		cbm := NewMux(st)
+		defer cbm.Close()
+
+		cbm.Get("key")
+
+		_, _, _, ok := cbm.AdaptiveStats("key")
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,99 @@
+package breakermux
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrBulkheadFull is returned when a key's bulkhead has no free slots and
+	// either its queue (Settings[T].MaxQueue) is already at capacity or
+	// Settings[T].AcquireTimeout is less than or equal to 0, so there is no
+	// waiting for one.
+	ErrBulkheadFull = errors.New("breakermux: bulkhead queue is full")
+	// ErrBulkheadTimeout is returned when a caller waited in a key's bulkhead
+	// queue for longer than Settings[T].AcquireTimeout without acquiring a slot.
+	ErrBulkheadTimeout = errors.New("breakermux: bulkhead acquire timed out")
+)
+
+// bulkhead is a bounded semaphore guarding a single key, so at most
+// maxConcurrent Executes are in flight for that key at once. Callers beyond
+// maxConcurrent queue, up to maxQueue deep, waiting for a slot.
+type bulkhead struct {
+	sem      chan struct{}
+	waiting  atomic.Int32
+	maxQueue int32
+}
+
+// newBulkhead returns a bulkhead allowing maxConcurrent in-flight Executes
+// and up to maxQueue callers waiting for a slot.
+func newBulkhead(maxConcurrent, maxQueue uint32) *bulkhead {
+	return &bulkhead{
+		sem:      make(chan struct{}, maxConcurrent),
+		maxQueue: int32(maxQueue),
+	}
+}
+
+// acquire reserves a slot, waiting up to timeout if none is immediately
+// available. It returns ErrBulkheadFull if no slot is free and either the
+// queue is already at capacity or timeout is less than or equal to 0, or
+// ErrBulkheadTimeout if timeout elapses first.
+func (b *bulkhead) acquire(timeout time.Duration) error {
+	select {
+	case b.sem <- struct{}{}:
+		// A slot was free; no need to queue at all.
+		return nil
+	default:
+	}
+
+	if timeout <= 0 {
+		return ErrBulkheadFull
+	}
+
+	// No slot free: we're about to actually queue for one, so this is the
+	// point maxQueue bounds -- waiting must only count callers genuinely
+	// blocked beyond maxConcurrent, not ones who just grabbed a free slot
+	// above, or the concurrency cap would effectively double as queue room.
+	if b.waiting.Add(1) > b.maxQueue {
+		b.waiting.Add(-1)
+		return ErrBulkheadFull
+	}
+	defer b.waiting.Add(-1)
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case b.sem <- struct{}{}:
+		return nil
+	case <-t.C:
+		return ErrBulkheadTimeout
+	}
+}
+
+// release frees a slot reserved by acquire.
+func (b *bulkhead) release() {
+	<-b.sem
+}
+
+// wrapBulkhead returns exec unchanged if bh is nil (bulkheading disabled for
+// this mux), otherwise returns a func that acquires a slot from bh before
+// calling exec and releases it afterward. An acquire failure is returned as
+// exec's own error, so it is counted as a failure by the 'breaker wrapping it,
+// same as any other error from exec.
+func (c *CircuitBreakerMux[T]) wrapBulkhead(bh *bulkhead, exec func() (T, error)) func() (T, error) {
+	if bh == nil {
+		return exec
+	}
+
+	return func() (T, error) {
+		if err := bh.acquire(c.acquireTimeout); err != nil {
+			var zero T
+			return zero, err
+		}
+		defer bh.release()
+
+		return exec()
+	}
+}
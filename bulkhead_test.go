@@ -0,0 +1,82 @@
+package breakermux
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBulkheadLimitsConcurrency(t *testing.T) {
+	Convey("When a mux has MaxConcurrent set, only that many Executes for a key run at once, and the rest queue for a slot.", t, func() {
+		var inFlight, maxInFlight int32
+		var mu sync.Mutex
+
+		st := Settings[string]{}
+		st.MaxConcurrent = 2
+		st.MaxQueue = 10
+		st.AcquireTimeout = time.Second
+		st.ExecClosure = func(input string) func() (string, error) {
+			return func() (string, error) {
+				mu.Lock()
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				mu.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				return "ok", nil
+			}
+		}
+
+		cbm := NewMux(st)
+		defer cbm.Close()
+
+		var wg sync.WaitGroup
+		for range 6 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cbm.Get("slow")
+			}()
+		}
+		wg.Wait()
+
+		So(maxInFlight, ShouldBeLessThanOrEqualTo, 2)
+	})
+}
+
+func TestBulkheadZeroQueueRejectsBeyondConcurrency(t *testing.T) {
+	Convey("Given a bulkhead with MaxQueue=0, a caller beyond MaxConcurrent is rejected immediately, even with AcquireTimeout set", t, func() {
+		bh := newBulkhead(2, 0)
+		So(bh.acquire(0), ShouldBeNil)
+		So(bh.acquire(0), ShouldBeNil)
+
+		err := bh.acquire(50 * time.Millisecond)
+		So(err, ShouldEqual, ErrBulkheadFull)
+	})
+}
+
+func TestBulkheadFullAndTimeout(t *testing.T) {
+	Convey("When a key's bulkhead queue is already at capacity, excess callers are rejected immediately with ErrBulkheadFull.", t, func() {
+		bh := newBulkhead(1, 0)
+		So(bh.acquire(0), ShouldBeNil)
+		So(bh.acquire(0), ShouldEqual, ErrBulkheadFull)
+		bh.release()
+
+		Convey("... and a caller that queues but times out waiting for a slot gets ErrBulkheadTimeout.", func() {
+			bh2 := newBulkhead(1, 1)
+			So(bh2.acquire(0), ShouldBeNil)
+
+			err := bh2.acquire(10 * time.Millisecond)
+			So(err, ShouldEqual, ErrBulkheadTimeout)
+			bh2.release()
+		})
+	})
+}
@@ -11,6 +11,11 @@ import (
 	"github.com/sony/gobreaker/v2"
 )
 
+// defaultEvictionCheck is the eviction ticker interval used when
+// Settings[T].MaxEntries is set but ExpireCheck is not. A var, not a const,
+// so tests can shorten it instead of waiting out the real interval.
+var defaultEvictionCheck = 30 * time.Second
+
 // CircuitBreakerMux is a goro-safe circuit breaker multiplex,
 // whereby individual keys gets their own 'breakers,
 // which can each be in various states. They must all share a return type.
@@ -19,10 +24,21 @@ import (
 // on the specificity of the executing request versus the granularity of the desired
 // circuit.
 type CircuitBreakerMux[T any] struct {
-	breakers sync.Map
-	st       gobreaker.Settings
-	efunc    ExecFunc[T]
-	killChan chan struct{}
+	breakers       sync.Map
+	st             gobreaker.Settings
+	efunc          ExecFunc[T]
+	killChan       chan struct{}
+	store          StateStore
+	userOSC        func(name string, from, to gobreaker.State)
+	adaptive       *AdaptiveSettings
+	isSuccessful   func(error) bool
+	maxConcurrent  uint32
+	maxQueue       uint32
+	acquireTimeout time.Duration
+	observer       Observer
+	retryPolicy    *RetryPolicy
+	maxEntries     uint32
+	evictionPolicy EvictionPolicy
 }
 
 // NewMux requires a Settings for proper configuration.
@@ -30,6 +46,37 @@ func NewMux[T any](st Settings[T]) *CircuitBreakerMux[T] {
 	var c CircuitBreakerMux[T]
 	c.killChan = make(chan struct{})
 
+	c.st = st.Settings
+	c.efunc = st.ExecClosure
+	c.userOSC = st.Settings.OnStateChange
+	c.adaptive = st.Adaptive
+	c.maxConcurrent = st.MaxConcurrent
+	c.maxQueue = st.MaxQueue
+	c.acquireTimeout = st.AcquireTimeout
+	c.observer = st.Observer
+	c.retryPolicy = st.RetryPolicy
+	c.maxEntries = st.MaxEntries
+	c.evictionPolicy = st.EvictionPolicy
+	if c.maxEntries > 0 && c.evictionPolicy == nil {
+		c.evictionPolicy = LRUEvictionPolicy
+	}
+
+	if st.IsSuccessful != nil {
+		c.isSuccessful = st.IsSuccessful
+	} else {
+		c.isSuccessful = func(err error) bool { return err == nil }
+	}
+
+	if st.StateStore != nil {
+		c.store = st.StateStore
+	} else {
+		c.store = newMemoryStateStore()
+	}
+
+	// Every field the background goroutines below read (c.maxEntries,
+	// c.evictionPolicy, via evict()) must already be set before they start,
+	// or -race reports a write/read race between here and their first tick.
+
 	if st.ExpireCheck > 0 {
 		if st.ExpireAfter <= 0 {
 			// clear the map each interval
@@ -55,14 +102,35 @@ func NewMux[T any](st Settings[T]) *CircuitBreakerMux[T] {
 						return
 					case <-time.After(st.ExpireCheck):
 						c.expire(time.Now().Add(st.ExpireAfter * -1))
+						c.evict()
 					}
 				}
 			}()
 		}
 	}
 
-	c.st = st.Settings
-	c.efunc = st.ExecClosure
+	// MaxEntries is normally kept in check by the traverse branch above, but
+	// that only runs when ExpireCheck and ExpireAfter are both set. A caller
+	// who sets MaxEntries alone (the usual case for bounding unbounded key
+	// cardinality, e.g. per-URL 'breakers for a crawler) still needs eviction
+	// to run somewhere, so give it its own ticker instead of growing forever.
+	if st.MaxEntries > 0 && !(st.ExpireCheck > 0 && st.ExpireAfter > 0) {
+		interval := st.ExpireCheck
+		if interval <= 0 {
+			interval = defaultEvictionCheck
+		}
+		go func() {
+			for {
+				select {
+				case <-c.killChan:
+					return
+				case <-time.After(interval):
+					c.evict()
+				}
+			}
+		}()
+	}
+
 	return &c
 }
 
@@ -72,28 +140,182 @@ func (c *CircuitBreakerMux[T]) Close() {
 	c.breakers.Clear() // low-level Clear() to avoid state changes.
 }
 
+// newBreaker builds a 'breaker for key, wiring its OnStateChange so
+// transitions are published to the configured StateStore, and (when
+// Settings[T].Adaptive is set) its ReadyToTrip to consult cba's EWMAs
+// instead of the static consecutive-failure count.
+func (c *CircuitBreakerMux[T]) newBreaker(key string, cba *cache) *gobreaker.CircuitBreaker[T] {
+	var ust = c.st
+	ust.Name = key
+
+	ust.OnStateChange = func(name string, from, to gobreaker.State) {
+		// gobreaker calls OnStateChange with its internal mutex held; publish
+		// off of that goroutine so a slow or contended StateStore never
+		// blocks concurrent Execute/State calls for the key (see publishState).
+		go c.publishState(key, to)
+		if c.observer != nil {
+			c.observer.OnStateChange(key, from, to)
+		}
+		if c.userOSC != nil {
+			c.userOSC(name, from, to)
+		}
+	}
+
+	if c.adaptive != nil {
+		ust.ReadyToTrip = adaptiveReadyToTrip(c.adaptive, cba)
+	}
+
+	return gobreaker.NewCircuitBreaker[T](ust)
+}
+
+// execute runs exec through cb, reporting the outcome to the configured
+// Observer, if any. A call that cb itself short-circuits (the 'breaker is
+// open, or too many requests are in flight while half-open) is reported
+// with shortCircuited=true and a zero duration, since exec never ran.
+func (c *CircuitBreakerMux[T]) execute(cb *gobreaker.CircuitBreaker[T], key string, exec func() (T, error)) (value T, err error) {
+	if c.observer == nil {
+		return cb.Execute(exec)
+	}
+
+	var ran bool
+	start := time.Now()
+	value, err = cb.Execute(func() (T, error) {
+		ran = true
+		return exec()
+	})
+
+	if ran {
+		c.observer.OnRequest(key, c.isSuccessful(err), false, time.Since(start))
+	} else {
+		c.observer.OnRequest(key, false, true, 0)
+	}
+
+	return value, err
+}
+
+// State reports the current state of key's 'breaker, and false if key has no
+// 'breaker yet.
+func (c *CircuitBreakerMux[T]) State(key string) (gobreaker.State, bool) {
+	cbaAny, ok := c.breakers.Load(key)
+	if !ok {
+		return gobreaker.StateClosed, false
+	}
+	cb := cbaAny.(*cache).item.(*gobreaker.CircuitBreaker[T])
+	return cb.State(), true
+}
+
+// wrapExec returns exec unchanged unless adaptive tripping is enabled, in
+// which case it times the call and folds its outcome into cba's EWMAs.
+func (c *CircuitBreakerMux[T]) wrapExec(cba *cache, exec func() (T, error)) func() (T, error) {
+	if c.adaptive == nil {
+		return exec
+	}
+
+	return func() (T, error) {
+		start := time.Now()
+		value, err := exec()
+		cba.updateAdaptive(c.adaptive, c.isSuccessful(err), time.Since(start))
+		return value, err
+	}
+}
+
+// publishStateAttempts bounds how many times publishState retries its CAS
+// loop against a concurrently-updating peer before giving up silently, the
+// same way a failed Save was always silently ignored.
+const publishStateAttempts = 5
+
+// publishState writes a 'breaker's current state to the configured
+// StateStore, so that other processes sharing this mux's key namespace can
+// observe the transition via remotelyOpen. It goes through CAS, retrying
+// against whatever is currently stored, so that two processes racing to
+// publish a transition for the same key (e.g. one closing while another is
+// opening) serialize instead of one silently clobbering the other.
+//
+// newBreaker's OnStateChange calls this in its own goroutine, not inline:
+// gobreaker invokes OnStateChange with its internal mutex held, and a
+// StateStore's CAS can mean real network I/O (e.g. RedisStateStore's
+// redsync lock plus Load/Save, retried up to publishStateAttempts times) --
+// running that under the 'breaker's lock would stall every concurrent
+// Execute/State on the key for as long as the store is slow or contended.
+//
+// This only ever propagates State and OpenedAt, never Counts: by the time
+// publishState's goroutine runs, gobreaker has already cleared Counts for
+// the new generation, and there is no safe way to re-enter the 'breaker to
+// fetch a fresher value (see newBreaker). Callers restarting or joining the
+// fleet hydrate the open/closed gate via remotelyOpen, not a Counts history.
+func (c *CircuitBreakerMux[T]) publishState(key string, state gobreaker.State) {
+	stored := StoredState{State: state}
+	if state == gobreaker.StateOpen {
+		stored.OpenedAt = time.Now()
+	}
+
+	for i := 0; i < publishStateAttempts; i++ {
+		cur, _, err := c.store.Load(key)
+		if err != nil {
+			return
+		}
+
+		ok, err := c.store.CAS(key, cur, stored)
+		if err != nil || ok {
+			return
+		}
+		// Another process updated key between our Load and our CAS; retry
+		// against whatever it left behind.
+	}
+}
+
+// remotelyOpen reports whether the StateStore believes key's 'breaker was
+// tripped open by some process in the fleet (possibly this one) and is still
+// within its timeout window. gobreaker has no way to inject this into a
+// freshly-created local 'breaker, so this is checked as a gate in front of
+// Execute instead -- which means a short-circuit here never reaches execute,
+// so Get and GetKeyExec report it to the Observer themselves.
+func (c *CircuitBreakerMux[T]) remotelyOpen(key string) bool {
+	stored, ok, err := c.store.Load(key)
+	if err != nil || !ok || stored.State != gobreaker.StateOpen {
+		return false
+	}
+
+	timeout := c.st.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	return time.Since(stored.OpenedAt) < timeout
+}
+
 // Get fetches an existing 'breaker for the key, or creates a new one,
 // executes the ExecFunc on it, and returns accordingly.
 func (c *CircuitBreakerMux[T]) Get(key string) (value T, err error) {
-	if cba, ok := c.breakers.Load(key); ok {
+	if c.remotelyOpen(key) {
+		if c.observer != nil {
+			c.observer.OnRequest(key, false, true, 0)
+		}
+		return value, gobreaker.ErrOpenState
+	}
+
+	if cbaAny, ok := c.breakers.Load(key); ok {
 		// Got one!
-		var cb = cba.(*cache).Get().(*gobreaker.CircuitBreaker[T])
-		value, err = cb.Execute(c.efunc(key))
+		cba := cbaAny.(*cache)
+		var cb = cba.Get().(*gobreaker.CircuitBreaker[T])
+		value, err = c.execute(cb, key, c.wrapBulkhead(cba.bh, c.wrapExec(cba, c.efunc(key))))
 	} else {
-		// Need a new one!
-		// Clone the default settings, update the name
-		var ust = c.st
-		ust.Name = key
-
-		// Create the cb, set it in the map
-		cb := gobreaker.NewCircuitBreaker[T](ust)
+		// Need a new one! Build it off to the side, then LoadOrStore so
+		// concurrent first-callers on a brand-new key all settle on the
+		// same cache/bulkhead/'breaker instead of each racing to create
+		// (and run against) their own.
+		cba := newCache()
+		if c.maxConcurrent > 0 {
+			cba.bh = newBulkhead(c.maxConcurrent, c.maxQueue)
+		}
+		cba.New(c.newBreaker(key, cba))
 
-		var cba cache
-		cba.New(cb)
-		c.breakers.Store(key, &cba)
+		if existing, loaded := c.breakers.LoadOrStore(key, cba); loaded {
+			cba = existing.(*cache)
+		}
+		cb := cba.Get().(*gobreaker.CircuitBreaker[T])
 
 		// Go for it!
-		value, err = cb.Execute(c.efunc(key))
+		value, err = c.execute(cb, key, c.wrapBulkhead(cba.bh, c.wrapExec(cba, c.efunc(key))))
 	}
 
 	return value, err
@@ -102,25 +324,36 @@ func (c *CircuitBreakerMux[T]) Get(key string) (value T, err error) {
 // GetKeyExec fetches an existing 'breaker for the key, or creates a new one,
 // passing exec to the ExecFunc, and returns accordingly.
 func (c *CircuitBreakerMux[T]) GetKeyExec(key, exec string) (value T, err error) {
-	if cba, ok := c.breakers.Load(key); ok {
+	if c.remotelyOpen(key) {
+		if c.observer != nil {
+			c.observer.OnRequest(key, false, true, 0)
+		}
+		return value, gobreaker.ErrOpenState
+	}
+
+	if cbaAny, ok := c.breakers.Load(key); ok {
 		// Got one!
-		var cb = cba.(*cache).Get().(*gobreaker.CircuitBreaker[T])
-		value, err = cb.Execute(c.efunc(exec))
+		cba := cbaAny.(*cache)
+		var cb = cba.Get().(*gobreaker.CircuitBreaker[T])
+		value, err = c.execute(cb, key, c.wrapBulkhead(cba.bh, c.wrapExec(cba, c.efunc(exec))))
 	} else {
-		// Need a new one!
-		// Clone the default settings, update the name
-		var ust = c.st
-		ust.Name = key
-
-		// Create the cb, set it in the map
-		cb := gobreaker.NewCircuitBreaker[T](ust)
+		// Need a new one! Build it off to the side, then LoadOrStore so
+		// concurrent first-callers on a brand-new key all settle on the
+		// same cache/bulkhead/'breaker instead of each racing to create
+		// (and run against) their own.
+		cba := newCache()
+		if c.maxConcurrent > 0 {
+			cba.bh = newBulkhead(c.maxConcurrent, c.maxQueue)
+		}
+		cba.New(c.newBreaker(key, cba))
 
-		var cba cache
-		cba.New(cb)
-		c.breakers.Store(key, &cba)
+		if existing, loaded := c.breakers.LoadOrStore(key, cba); loaded {
+			cba = existing.(*cache)
+		}
+		cb := cba.Get().(*gobreaker.CircuitBreaker[T])
 
 		// Go for it!
-		value, err = cb.Execute(c.efunc(exec))
+		value, err = c.execute(cb, key, c.wrapBulkhead(cba.bh, c.wrapExec(cba, c.efunc(exec))))
 	}
 
 	return value, err
@@ -159,6 +392,21 @@ func (c *CircuitBreakerMux[T]) expire(deadtime time.Time) {
 // ExecFunc is a closure to allow a string to be passed to an otherwise niladic function.
 type ExecFunc[T any] func(string) func() (T, error)
 
+// Observer receives per-request and per-transition events from a mux, for
+// instrumentation (metrics, tracing, logging) without reaching into the
+// mux's internals. Implementations must be goro-safe, since they are called
+// from whatever goroutines call Get/GetKeyExec and from gobreaker's own
+// OnStateChange path.
+type Observer interface {
+	// OnRequest is called once per Get/GetKeyExec call, after it completes.
+	// shortCircuited is true if the 'breaker itself rejected the call (open,
+	// or too many requests while half-open), in which case exec never ran
+	// and duration is zero.
+	OnRequest(key string, success, shortCircuited bool, duration time.Duration)
+	// OnStateChange is called whenever a key's 'breaker changes state.
+	OnStateChange(key string, from, to gobreaker.State)
+}
+
 // Settings allows for per-mux and per-'breaker configurations. Changing values after passing it to
 // NewMux is undefined.
 //
@@ -198,11 +446,71 @@ type ExecFunc[T any] func(string) func() (T, error)
 // ExpireCheck is an interval when expiration checks will be performed.
 // Overly aggressive expiration is not advised.
 // If ExpireCheck is less than or equal to 0, expiration will not occur.
+//
+// StateStore, if set, backs 'breaker state with a shared store instead of
+// keeping it local to this process: every state transition is published to
+// it (serialized via CAS against concurrent peers), and every call gates on
+// whether it currently reports the key as open, so a fleet of servers
+// sharing a mux key all reject calls while any of them has the 'breaker
+// tripped. It does not hydrate a rejoining or restarted process's Counts or
+// half-open probing state, both of which remain local to the 'breaker that
+// produced them. If StateStore is nil, an in-memory default is used,
+// preserving the pre-existing single-process behavior.
+//
+// Adaptive, if set, replaces gobreaker's static ReadyToTrip with one driven
+// by per-key EWMAs of error-rate and latency. Setting Adaptive also causes
+// ReadyToTrip above to be ignored. If Adaptive is nil, the static behavior
+// described under ReadyToTrip is used, unchanged.
+//
+// MaxConcurrent, if greater than 0, bounds the number of concurrent Executes
+// in flight for any one key (a bulkhead), isolating slow keys from one
+// another instead of letting them exhaust shared resources. If MaxConcurrent
+// is 0, no bulkhead is applied.
+//
+// MaxQueue bounds how many callers may be waiting for a bulkhead slot beyond
+// MaxConcurrent before ErrBulkheadFull is returned immediately. It has no
+// effect if MaxConcurrent is 0.
+//
+// AcquireTimeout bounds how long a queued caller waits for a bulkhead slot
+// before ErrBulkheadTimeout is returned. If AcquireTimeout is less than or
+// equal to 0, a caller that doesn't queue-reject acquires a slot immediately
+// or not at all. It has no effect if MaxConcurrent is 0.
+//
+// Observer, if set, is notified of every request's outcome and every
+// 'breaker state transition, for instrumentation. If Observer is nil, no
+// instrumentation overhead is added.
+//
+// RetryPolicy, if set, is used by GetWithRetry and GetKeyExecWithRetry to
+// retry a failed call with exponential backoff, jittered if its Jitter is
+// set. It has no effect on
+// Get or GetKeyExec. If RetryPolicy is nil, GetWithRetry and
+// GetKeyExecWithRetry behave like Get and GetKeyExec (a single attempt).
+//
+// MaxEntries, if greater than 0, bounds how many 'breakers the mux keeps at
+// once: whenever the count exceeds MaxEntries, EvictionPolicy is called to
+// pick which to remove. If ExpireAfter and ExpireCheck are both also set,
+// eviction piggybacks on their periodic traversal; otherwise MaxEntries
+// starts its own ticker, using ExpireCheck as its interval if set, or
+// defaultEvictionCheck if not, so MaxEntries alone is still enforced.
+//
+// EvictionPolicy picks which keys to remove once the 'breaker count exceeds
+// MaxEntries; see LRUEvictionPolicy, LFUEvictionPolicy, and
+// TTLEvictionPolicy. If MaxEntries is set and EvictionPolicy is nil,
+// LRUEvictionPolicy is used.
 type Settings[T any] struct {
 	gobreaker.Settings
-	ExecClosure func(string) func() (T, error)
-	ExpireAfter time.Duration
-	ExpireCheck time.Duration
+	ExecClosure    func(string) func() (T, error)
+	ExpireAfter    time.Duration
+	ExpireCheck    time.Duration
+	StateStore     StateStore
+	Adaptive       *AdaptiveSettings
+	MaxConcurrent  uint32
+	MaxQueue       uint32
+	AcquireTimeout time.Duration
+	Observer       Observer
+	RetryPolicy    *RetryPolicy
+	MaxEntries     uint32
+	EvictionPolicy EvictionPolicy
 }
 
 // cache is an internal-only storable, that when used properly allows for fast
@@ -216,6 +524,27 @@ type cache struct {
 	item  any
 	atime atomic.Int64
 	mtime atomic.Int64
+
+	// The following are only touched when Settings[T].Adaptive is set; see
+	// adaptive.go. float64s are stored via math.Float64bits so updates stay
+	// lock-free on the hot path.
+	errEWMA     atomic.Uint64
+	latEWMA     atomic.Uint64
+	baseEWMA    atomic.Uint64
+	ewmaUpdated atomic.Int64
+	ewmaCount   atomic.Uint32
+
+	// bh is only set when Settings[T].MaxConcurrent is set; see bulkhead.go.
+	bh *bulkhead
+
+	// accessCount is only consulted when Settings[T].MaxEntries is set; see
+	// eviction.go.
+	accessCount atomic.Uint64
+}
+
+// newCache returns an empty, ready-to-use cache.
+func newCache() *cache {
+	return &cache{}
 }
 
 // New sets atime and mtime, ad stores the item.
@@ -227,9 +556,10 @@ func (c *cache) New(item any) {
 	c.item = item
 }
 
-// Get updates atime, and returns the item.
+// Get updates atime and accessCount, and returns the item.
 func (c *cache) Get() any {
 	c.atime.Store(time.Now().UnixMicro())
+	c.accessCount.Add(1)
 	return c.item
 }
 
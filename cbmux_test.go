@@ -304,6 +304,66 @@ func TestMuxExpireClear(t *testing.T) {
 	})
 }
 
+// observerSpy records every OnRequest call it receives, for assertions.
+type observerSpy struct {
+	calls []struct {
+		key            string
+		success        bool
+		shortCircuited bool
+	}
+}
+
+func (o *observerSpy) OnRequest(key string, success, shortCircuited bool, duration time.Duration) {
+	o.calls = append(o.calls, struct {
+		key            string
+		success        bool
+		shortCircuited bool
+	}{key, success, shortCircuited})
+}
+
+func (o *observerSpy) OnStateChange(key string, from, to gobreaker.State) {}
+
+func TestMuxObserverSeesRemotelyOpenShortCircuit(t *testing.T) {
+	defer leaktest.Check(t)()
+
+	spy := &observerSpy{}
+
+	st := Settings[string]{}
+	st.ReadyToTrip = func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= 1 }
+	st.Observer = spy
+	st.ExecClosure = func(input string) func() (string, error) {
+		return func() (string, error) {
+			return "", fmt.Errorf("Noo")
+		}
+	}
+
+	cbm := NewMux(st)
+	defer cbm.Close()
+
+	Convey("Given a mux whose 'breaker has tripped open (and so been published to the StateStore)", t, func() {
+		_, err := cbm.Get("no")
+		So(err, ShouldNotBeNil)
+
+		// publishState runs off of OnStateChange's own goroutine (see
+		// cbmux.go), so give it a moment to land before relying on
+		// remotelyOpen seeing it.
+		for i := 0; i < 100 && !cbm.remotelyOpen("no"); i++ {
+			time.Sleep(time.Millisecond)
+		}
+		So(cbm.remotelyOpen("no"), ShouldBeTrue)
+
+		Convey("A subsequent call, short-circuited by remotelyOpen before the 'breaker is ever consulted, is still reported to the Observer", func() {
+			_, err := cbm.Get("no")
+			So(err, ShouldEqual, gobreaker.ErrOpenState)
+
+			last := spy.calls[len(spy.calls)-1]
+			So(last.key, ShouldEqual, "no")
+			So(last.success, ShouldBeFalse)
+			So(last.shortCircuited, ShouldBeTrue)
+		})
+	})
+}
+
 // Benchmark_HttpGet loops a function that is like an ExecFunc, that http.Get's a URL and returns the read body or an error.
 func Benchmark_HttpGet(b *testing.B) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
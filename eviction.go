@@ -0,0 +1,93 @@
+package breakermux
+
+import (
+	"sort"
+	"time"
+)
+
+// EntryStat is a snapshot of one 'breaker's cache bookkeeping, passed to an
+// EvictionPolicy so it can decide which keys to evict.
+type EntryStat struct {
+	Key         string
+	Atime       time.Time
+	Mtime       time.Time
+	AccessCount uint64
+}
+
+// EvictionPolicy picks which keys to remove when a mux's 'breaker count
+// exceeds Settings[T].MaxEntries. It is given every current entry and the
+// number of entries that must be evicted to get back down to MaxEntries,
+// and returns the keys to remove; returning fewer than excess keys is
+// allowed (eviction will simply run again next tick), and returning more is
+// harmless since they're all removed anyway.
+type EvictionPolicy func(entries []EntryStat, excess int) []string
+
+// LRUEvictionPolicy evicts the excess least-recently-accessed entries.
+func LRUEvictionPolicy(entries []EntryStat, excess int) []string {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Atime.Before(entries[j].Atime)
+	})
+	return evictKeys(entries, excess)
+}
+
+// LFUEvictionPolicy evicts the excess least-frequently-accessed entries.
+func LFUEvictionPolicy(entries []EntryStat, excess int) []string {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AccessCount < entries[j].AccessCount
+	})
+	return evictKeys(entries, excess)
+}
+
+// TTLEvictionPolicy evicts the excess oldest-by-Mtime entries, i.e. the
+// 'breakers created or replaced longest ago, regardless of how recently they
+// were accessed.
+func TTLEvictionPolicy(entries []EntryStat, excess int) []string {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Mtime.Before(entries[j].Mtime)
+	})
+	return evictKeys(entries, excess)
+}
+
+// evictKeys returns the keys of the first excess entries, already sorted
+// worst-first by the caller.
+func evictKeys(entries []EntryStat, excess int) []string {
+	if excess > len(entries) {
+		excess = len(entries)
+	}
+
+	keys := make([]string, excess)
+	for i := range keys {
+		keys[i] = entries[i].Key
+	}
+	return keys
+}
+
+// evict trims the mux's 'breaker count down to maxEntries, if set, by
+// consulting evictionPolicy with a snapshot of every current entry's
+// bookkeeping.
+func (c *CircuitBreakerMux[T]) evict() {
+	if c.maxEntries == 0 || c.evictionPolicy == nil {
+		return
+	}
+
+	var entries []EntryStat
+	c.breakers.Range(func(key, value any) bool {
+		cba := value.(*cache)
+		entries = append(entries, EntryStat{
+			Key:         key.(string),
+			Atime:       cba.Atime(),
+			Mtime:       cba.Mtime(),
+			AccessCount: cba.accessCount.Load(),
+		})
+		return true
+	})
+
+	excess := len(entries) - int(c.maxEntries)
+	if excess <= 0 {
+		return
+	}
+
+	for _, key := range c.evictionPolicy(entries, excess) {
+		c.breakers.Delete(key)
+	}
+}
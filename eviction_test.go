@@ -0,0 +1,117 @@
+package breakermux
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLRUEvictionPolicyEvictsOldestAccess(t *testing.T) {
+	Convey("Given entries with distinct access times", t, func() {
+		now := time.Now()
+		entries := []EntryStat{
+			{Key: "newest", Atime: now},
+			{Key: "middle", Atime: now.Add(-time.Minute)},
+			{Key: "oldest", Atime: now.Add(-time.Hour)},
+		}
+
+		Convey("LRUEvictionPolicy evicts the least-recently-accessed entries first", func() {
+			evicted := LRUEvictionPolicy(entries, 2)
+			So(evicted, ShouldResemble, []string{"oldest", "middle"})
+		})
+	})
+}
+
+func TestLFUEvictionPolicyEvictsLeastAccessed(t *testing.T) {
+	Convey("Given entries with distinct access counts", t, func() {
+		entries := []EntryStat{
+			{Key: "hot", AccessCount: 100},
+			{Key: "warm", AccessCount: 10},
+			{Key: "cold", AccessCount: 1},
+		}
+
+		Convey("LFUEvictionPolicy evicts the least-frequently-accessed entry first", func() {
+			evicted := LFUEvictionPolicy(entries, 1)
+			So(evicted, ShouldResemble, []string{"cold"})
+		})
+	})
+}
+
+func TestTTLEvictionPolicyEvictsOldestModification(t *testing.T) {
+	Convey("Given entries with distinct modification times", t, func() {
+		now := time.Now()
+		entries := []EntryStat{
+			{Key: "newest", Mtime: now},
+			{Key: "middle", Mtime: now.Add(-time.Minute)},
+			{Key: "oldest", Mtime: now.Add(-time.Hour)},
+		}
+
+		Convey("TTLEvictionPolicy evicts the oldest-created-or-replaced entries first", func() {
+			evicted := TTLEvictionPolicy(entries, 2)
+			So(evicted, ShouldResemble, []string{"oldest", "middle"})
+		})
+	})
+}
+
+func TestMuxEvictsDownToMaxEntriesWithoutExpireCheck(t *testing.T) {
+	Convey("Given a mux with MaxEntries set and no ExpireCheck at all", t, func() {
+		old := defaultEvictionCheck
+		defaultEvictionCheck = 10 * time.Millisecond
+		defer func() { defaultEvictionCheck = old }()
+
+		st := Settings[string]{}
+		st.MaxEntries = 2
+		st.ExecClosure = func(key string) func() (string, error) {
+			return func() (string, error) { return key, nil }
+		}
+
+		cbm := NewMux(st)
+		defer cbm.Close()
+
+		_, _ = cbm.Get("a")
+		_, _ = cbm.Get("b")
+		_, _ = cbm.Get("c")
+
+		Convey("MaxEntries is still enforced via its own ticker", func() {
+			time.Sleep(50 * time.Millisecond)
+
+			var count int
+			cbm.breakers.Range(func(_, _ any) bool {
+				count++
+				return true
+			})
+			So(count, ShouldEqual, 2)
+		})
+	})
+}
+
+func TestMuxEvictsDownToMaxEntries(t *testing.T) {
+	Convey("Given a mux with MaxEntries set and a fast ExpireCheck", t, func() {
+		st := Settings[string]{}
+		st.ExpireAfter = time.Hour // keep TTL expiry from interfering
+		st.ExpireCheck = 10 * time.Millisecond
+		st.MaxEntries = 2
+		st.ExecClosure = func(key string) func() (string, error) {
+			return func() (string, error) { return key, nil }
+		}
+
+		cbm := NewMux(st)
+		defer cbm.Close()
+
+		_, _ = cbm.Get("a")
+		_, _ = cbm.Get("b")
+		_, _ = cbm.Get("c")
+
+		Convey("The next ExpireCheck tick evicts down to MaxEntries", func() {
+			time.Sleep(50 * time.Millisecond)
+
+			var count int
+			cbm.breakers.Range(func(_, _ any) bool {
+				count++
+				return true
+			})
+			So(count, ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,238 @@
+// Package httpmux integrates breakermux.CircuitBreakerMux with net/http,
+// providing a client-side RoundTripper and a server-side Handler middleware
+// keyed by host, route, or any other function of the request.
+package httpmux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	breakermux "github.com/cognusion/go-breakermux"
+	"github.com/sony/gobreaker/v2"
+)
+
+// IsSuccessful classifies a RoundTrip outcome for 'breaker accounting.
+// Unlike gobreaker's own IsSuccessful, it also sees the response, so it can
+// treat e.g. a 5xx or 429 as a failure even though the transport itself
+// returned no error.
+type IsSuccessful func(resp *http.Response, err error) bool
+
+// DefaultIsSuccessful treats transport errors, 5xx responses, and 429
+// responses as failures; everything else is a success.
+func DefaultIsSuccessful(resp *http.Response, err error) bool {
+	if err != nil {
+		return false
+	}
+	return resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests
+}
+
+// HostKey is the default key function for NewTransport: it 'breaks per
+// request host.
+func HostKey(req *http.Request) string {
+	return req.URL.Host
+}
+
+// classifiedFailure wraps a successfully-received *http.Response that
+// IsSuccessful classified as a failure. Returning it as the ExecClosure's
+// error lets gobreaker's error-based accounting count the failure, while
+// RoundTrip unwraps it back into a normal response for the caller.
+type classifiedFailure struct {
+	resp *http.Response
+}
+
+func (classifiedFailure) Error() string { return "httpmux: response classified as a failure" }
+
+// coolingDown is returned by the ExecClosure in place of making a real
+// RoundTrip when a prior response for this key carried a Retry-After header
+// whose window hasn't elapsed yet. It is returned as the ExecClosure's own
+// error, so it flows through cb.Execute like any other failure: it is
+// counted toward the 'breaker's ReadyToTrip threshold and reported to
+// Observer the same as a real failed request, instead of short-circuiting
+// outside the 'breaker where neither would ever see it.
+type coolingDown struct {
+	key   string
+	until time.Time
+}
+
+func (c coolingDown) Error() string {
+	return fmt.Sprintf("httpmux: %q is cooling down until %s per Retry-After", c.key, c.until.Format(time.RFC3339))
+}
+
+// Transport is an http.RoundTripper that multiplexes a
+// breakermux.CircuitBreakerMux[*http.Response] keyed by host (or keyFunc, if
+// given), so a failing upstream trips its own 'breaker instead of every
+// destination sharing one.
+type Transport struct {
+	base         http.RoundTripper
+	keyFunc      func(*http.Request) string
+	isSuccessful IsSuccessful
+	mux          *breakermux.CircuitBreakerMux[*http.Response]
+	inflight     sync.Map // nonce (string) -> *http.Request
+	seq          atomic.Uint64
+	cooldowns    sync.Map // key (string) -> time.Time, from a Retry-After hint
+}
+
+// NewTransport wraps base (http.DefaultTransport if nil) with a per-key
+// circuit breaker. keyFunc extracts the 'breaker key from a request (HostKey
+// if nil). isSuccessful classifies responses for 'breaker accounting
+// (DefaultIsSuccessful if nil). st configures the underlying mux; its
+// ExecClosure is overwritten to integrate with RoundTrip.
+func NewTransport(base http.RoundTripper, keyFunc func(*http.Request) string, isSuccessful IsSuccessful, st breakermux.Settings[*http.Response]) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if keyFunc == nil {
+		keyFunc = HostKey
+	}
+	if isSuccessful == nil {
+		isSuccessful = DefaultIsSuccessful
+	}
+
+	t := &Transport{base: base, keyFunc: keyFunc, isSuccessful: isSuccessful}
+
+	st.ExecClosure = func(nonce string) func() (*http.Response, error) {
+		return func() (*http.Response, error) {
+			req := t.request(nonce)
+			key := t.keyFunc(req)
+
+			if until, ok := t.cooldowns.Load(key); ok {
+				if u := until.(time.Time); time.Now().Before(u) {
+					return nil, coolingDown{key: key, until: u}
+				}
+				t.cooldowns.Delete(key)
+			}
+
+			resp, err := t.base.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			if t.isSuccessful(resp, nil) {
+				return resp, nil
+			}
+
+			if ra, ok := retryAfter(resp); ok {
+				t.cooldowns.Store(key, time.Now().Add(ra))
+			}
+			return resp, classifiedFailure{resp: resp}
+		}
+	}
+
+	t.mux = breakermux.NewMux(st)
+	return t
+}
+
+func (t *Transport) request(nonce string) *http.Request {
+	v, _ := t.inflight.Load(nonce)
+	return v.(*http.Request)
+}
+
+// RoundTrip implements http.RoundTripper. Every call goes through the
+// 'breaker for req's key, including ones currently cooling down per a prior
+// Retry-After, so the 'breaker's own ReadyToTrip threshold and Observer see
+// every outcome; only the decision to skip making a real request while
+// cooling down happens inside the ExecClosure itself.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.keyFunc(req)
+
+	nonce := strconv.FormatUint(t.seq.Add(1), 10)
+	t.inflight.Store(nonce, req)
+	defer t.inflight.Delete(nonce)
+
+	resp, err := t.mux.GetKeyExec(key, nonce)
+
+	var cf classifiedFailure
+	if errors.As(err, &cf) {
+		return cf.resp, nil
+	}
+	return resp, err
+}
+
+// retryAfter parses the Retry-After header from resp, if present, as either
+// a delay in seconds or an HTTP-date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if when, err := http.ParseTime(v); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// requestPair bundles the ResponseWriter and Request Middleware's
+// ExecClosure needs to invoke the wrapped Handler.
+type requestPair struct {
+	w http.ResponseWriter
+	r *http.Request
+}
+
+// Middleware wraps next with a breakermux.CircuitBreakerMux[int] keyed by
+// keyFunc (the request path, if keyFunc is nil), sheds load with a 503 once
+// the 'breaker for a route-key is open, and otherwise classifies next's
+// response status via the same rules as DefaultIsSuccessful.
+func Middleware(next http.Handler, keyFunc func(*http.Request) string, st breakermux.Settings[int]) http.Handler {
+	if keyFunc == nil {
+		keyFunc = func(req *http.Request) string { return req.URL.Path }
+	}
+
+	var inflight sync.Map // nonce (string) -> *requestPair
+	var seq atomic.Uint64
+
+	st.ExecClosure = func(nonce string) func() (int, error) {
+		return func() (int, error) {
+			v, _ := inflight.Load(nonce)
+			pair := v.(*requestPair)
+
+			rec := &statusRecorder{ResponseWriter: pair.w, status: http.StatusOK}
+			next.ServeHTTP(rec, pair.r)
+
+			if rec.status >= 500 || rec.status == http.StatusTooManyRequests {
+				return rec.status, fmt.Errorf("httpmux: handler returned status %d", rec.status)
+			}
+			return rec.status, nil
+		}
+	}
+
+	mux := breakermux.NewMux(st)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := keyFunc(r)
+
+		nonce := strconv.FormatUint(seq.Add(1), 10)
+		inflight.Store(nonce, &requestPair{w: w, r: r})
+		defer inflight.Delete(nonce)
+
+		_, err := mux.GetKeyExec(key, nonce)
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) ||
+			errors.Is(err, breakermux.ErrBulkheadFull) || errors.Is(err, breakermux.ErrBulkheadTimeout) {
+			// The 'breaker short-circuited, or the key's bulkhead rejected the
+			// call, before next ever ran, so nothing has been written to w yet.
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+		// Otherwise next.ServeHTTP already wrote the response, classified
+		// failure or not.
+	})
+}
+
+// statusRecorder captures the status code a Handler wrote, so Middleware can
+// classify it without buffering the body.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
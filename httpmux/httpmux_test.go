@@ -0,0 +1,109 @@
+package httpmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	breakermux "github.com/cognusion/go-breakermux"
+	"github.com/sony/gobreaker/v2"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTransportTripsOnServerErrors(t *testing.T) {
+	Convey("When a server keeps returning 500s, the Transport's 'breaker trips and short-circuits further requests.", t, func() {
+		var hits int
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		st := breakermux.Settings[*http.Response]{}
+		st.Timeout = time.Hour
+
+		rt := NewTransport(nil, nil, nil, st)
+		client := &http.Client{Transport: rt}
+
+		var lastErr error
+		for range 10 {
+			resp, err := client.Get(ts.URL)
+			lastErr = err
+			if resp != nil {
+				resp.Body.Close()
+			}
+		}
+
+		So(lastErr, ShouldNotBeNil)
+		So(hits, ShouldBeLessThan, 10)
+	})
+}
+
+func TestTransportHonorsRetryAfterWithoutBypassingTheBreaker(t *testing.T) {
+	Convey("When a response carries a Retry-After header, the Transport stops hitting the server but every call still goes through the 'breaker.", t, func() {
+		var hits int
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+
+		var transitions []gobreaker.State
+		st := breakermux.Settings[*http.Response]{}
+		st.Timeout = time.Hour
+		st.ReadyToTrip = func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= 2 }
+		st.OnStateChange = func(name string, from, to gobreaker.State) {
+			transitions = append(transitions, to)
+		}
+
+		rt := NewTransport(nil, nil, nil, st)
+		client := &http.Client{Transport: rt}
+
+		resp, err := client.Get(ts.URL)
+		So(err, ShouldBeNil)
+		resp.Body.Close()
+		So(hits, ShouldEqual, 1)
+
+		Convey("A second call within the Retry-After window makes no further request to the server, but is still observed as a 'breaker failure", func() {
+			_, err := client.Get(ts.URL)
+			So(err, ShouldNotBeNil)
+			So(hits, ShouldEqual, 1) // no new hit: the real RoundTrip was skipped
+
+			// The cooling-down error was counted like any other failure, so
+			// it contributed to the same Counts ReadyToTrip consults,
+			// rather than being invisible to the 'breaker entirely.
+			So(len(transitions), ShouldBeGreaterThan, 0)
+		})
+	})
+}
+
+func TestMiddlewareShedsLoadWhenOpen(t *testing.T) {
+	Convey("When the wrapped Handler keeps failing, Middleware sheds load with a 503 instead of calling it.", t, func() {
+		var hits int
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		st := breakermux.Settings[int]{}
+		st.Timeout = time.Hour
+
+		h := Middleware(next, nil, st)
+		ts := httptest.NewServer(h)
+		defer ts.Close()
+
+		var lastStatus int
+		for range 10 {
+			resp, err := http.Get(ts.URL)
+			So(err, ShouldBeNil)
+			lastStatus = resp.StatusCode
+			resp.Body.Close()
+		}
+
+		So(lastStatus, ShouldEqual, http.StatusServiceUnavailable)
+		So(hits, ShouldBeLessThan, 10)
+	})
+}
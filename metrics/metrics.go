@@ -0,0 +1,160 @@
+// Package metrics adapts breakermux.Observer to a prometheus.Collector, so a
+// mux's per-key request and state-transition counts can be scraped directly.
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	breakermux "github.com/cognusion/go-breakermux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker/v2"
+)
+
+var (
+	requestsDesc = prometheus.NewDesc(
+		"breakermux_requests_total",
+		"Total requests seen by a 'breaker key, labeled by outcome.",
+		[]string{"key", "outcome"}, nil,
+	)
+	transitionsDesc = prometheus.NewDesc(
+		"breakermux_state_transitions_total",
+		"Total 'breaker state transitions, labeled by key and the state transitioned to.",
+		[]string{"key", "state"}, nil,
+	)
+	stateDesc = prometheus.NewDesc(
+		"breakermux_state",
+		"Current 'breaker state for a key (0=closed, 1=half-open, 2=open).",
+		[]string{"key"}, nil,
+	)
+)
+
+// keyStats holds the lock-free counters tracked for a single 'breaker key.
+type keyStats struct {
+	successes     atomic.Uint64
+	failures      atomic.Uint64
+	shortCircuits atomic.Uint64
+	state         atomic.Int32
+	closed        atomic.Uint64
+	halfOpen      atomic.Uint64
+	open          atomic.Uint64
+}
+
+// Metrics is a breakermux.Observer that also implements prometheus.Collector,
+// exposing the per-key counts it observes as metrics. To bound cardinality in
+// the face of unbounded or attacker-influenced keys, it tracks at most
+// maxKeys distinct keys; observations for further keys are folded into a
+// single "other" series instead of growing without limit.
+type Metrics struct {
+	maxKeys int
+	keys    sync.Map // string -> *keyStats
+	seen    atomic.Int64
+	latency *prometheus.HistogramVec
+}
+
+// NewMetrics returns a Metrics tracking at most maxKeys distinct 'breaker
+// keys as their own series. If maxKeys is less than or equal to 0, it
+// defaults to 1000.
+func NewMetrics(maxKeys int) *Metrics {
+	if maxKeys <= 0 {
+		maxKeys = 1000
+	}
+	return &Metrics{
+		maxKeys: maxKeys,
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "breakermux_request_duration_seconds",
+			Help:    "Observed latency of requests that were not short-circuited, labeled by 'breaker key.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"key"}),
+	}
+}
+
+const overflowKey = "other"
+
+// statsFor returns the label and keyStats to record an observation under,
+// routing to a shared overflow bucket once maxKeys distinct keys have been
+// seen. The returned label is also what latency observations are recorded
+// under, so the two never disagree about which series a key landed in.
+func (m *Metrics) statsFor(key string) (string, *keyStats) {
+	if v, ok := m.keys.Load(key); ok {
+		return key, v.(*keyStats)
+	}
+
+	if int(m.seen.Load()) >= m.maxKeys {
+		key = overflowKey
+		if v, ok := m.keys.Load(key); ok {
+			return key, v.(*keyStats)
+		}
+	}
+
+	ks := &keyStats{}
+	actual, loaded := m.keys.LoadOrStore(key, ks)
+	if !loaded {
+		m.seen.Add(1)
+	}
+	return key, actual.(*keyStats)
+}
+
+// OnRequest implements breakermux.Observer.
+func (m *Metrics) OnRequest(key string, success, shortCircuited bool, duration time.Duration) {
+	label, ks := m.statsFor(key)
+
+	switch {
+	case shortCircuited:
+		ks.shortCircuits.Add(1)
+	case success:
+		ks.successes.Add(1)
+		m.latency.WithLabelValues(label).Observe(duration.Seconds())
+	default:
+		ks.failures.Add(1)
+		m.latency.WithLabelValues(label).Observe(duration.Seconds())
+	}
+}
+
+// OnStateChange implements breakermux.Observer.
+func (m *Metrics) OnStateChange(key string, from, to gobreaker.State) {
+	_, ks := m.statsFor(key)
+
+	switch to {
+	case gobreaker.StateClosed:
+		ks.closed.Add(1)
+	case gobreaker.StateHalfOpen:
+		ks.halfOpen.Add(1)
+	case gobreaker.StateOpen:
+		ks.open.Add(1)
+	}
+	ks.state.Store(int32(to))
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- requestsDesc
+	ch <- transitionsDesc
+	ch <- stateDesc
+	m.latency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.keys.Range(func(k, v any) bool {
+		key := k.(string)
+		ks := v.(*keyStats)
+
+		ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.CounterValue, float64(ks.successes.Load()), key, "success")
+		ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.CounterValue, float64(ks.failures.Load()), key, "failure")
+		ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.CounterValue, float64(ks.shortCircuits.Load()), key, "short_circuited")
+
+		ch <- prometheus.MustNewConstMetric(transitionsDesc, prometheus.CounterValue, float64(ks.closed.Load()), key, "closed")
+		ch <- prometheus.MustNewConstMetric(transitionsDesc, prometheus.CounterValue, float64(ks.halfOpen.Load()), key, "half_open")
+		ch <- prometheus.MustNewConstMetric(transitionsDesc, prometheus.CounterValue, float64(ks.open.Load()), key, "open")
+
+		ch <- prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, float64(ks.state.Load()), key)
+
+		return true
+	})
+	m.latency.Collect(ch)
+}
+
+var _ breakermux.Observer = (*Metrics)(nil)
+var _ prometheus.Collector = (*Metrics)(nil)
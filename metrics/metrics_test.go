@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker/v2"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMetricsCollectsObservedCounts(t *testing.T) {
+	Convey("Given a Metrics fed some requests and a state transition", t, func() {
+		m := NewMetrics(10)
+		m.OnRequest("svc", true, false, 5*time.Millisecond)
+		m.OnRequest("svc", false, false, 5*time.Millisecond)
+		m.OnRequest("svc", false, true, 0)
+		m.OnStateChange("svc", gobreaker.StateClosed, gobreaker.StateOpen)
+
+		Convey("Collect emits a metric for each counter it tracked", func() {
+			ch := make(chan prometheus.Metric, 32)
+			m.Collect(ch)
+			close(ch)
+
+			var count int
+			for range ch {
+				count++
+			}
+			So(count, ShouldBeGreaterThanOrEqualTo, 7)
+		})
+	})
+}
+
+func TestMetricsLatencyIsAHistogramNotAnAverage(t *testing.T) {
+	Convey("Given a Metrics fed latencies spanning several orders of magnitude", t, func() {
+		m := NewMetrics(10)
+		m.OnRequest("svc", true, false, time.Millisecond)
+		m.OnRequest("svc", true, false, 2*time.Second)
+
+		Convey("Collect emits a histogram preserving the distribution, not just a mean", func() {
+			ch := make(chan prometheus.Metric, 32)
+			m.Collect(ch)
+			close(ch)
+
+			var hist *dto.Histogram
+			for metric := range ch {
+				var pb dto.Metric
+				if err := metric.Write(&pb); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+				if pb.Histogram != nil {
+					hist = pb.Histogram
+				}
+			}
+
+			So(hist, ShouldNotBeNil)
+			So(hist.GetSampleCount(), ShouldEqual, 2)
+
+			// The 1ms and 2s observations land in different
+			// buckets, so the cumulative counts climb from 1 to 2 partway
+			// through instead of every bucket reading the full count the way
+			// a single averaged gauge would collapse them down to one number.
+			var sawPartial bool
+			for _, b := range hist.GetBucket() {
+				if b.GetCumulativeCount() > 0 && b.GetCumulativeCount() < hist.GetSampleCount() {
+					sawPartial = true
+				}
+			}
+			So(sawPartial, ShouldBeTrue)
+		})
+	})
+}
+
+func TestMetricsCardinalityCap(t *testing.T) {
+	Convey("Given a Metrics capped at 1 key", t, func() {
+		m := NewMetrics(1)
+		m.OnRequest("a", true, false, time.Millisecond)
+		m.OnRequest("b", true, false, time.Millisecond)
+		m.OnRequest("c", true, false, time.Millisecond)
+
+		Convey("Only one key's worth of series plus the overflow bucket are tracked", func() {
+			var keys int
+			m.keys.Range(func(_, _ any) bool {
+				keys++
+				return true
+			})
+			So(keys, ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,106 @@
+// Package otel instruments a breakermux.CircuitBreakerMux with OpenTelemetry
+// spans, recording the 'breaker's key and state as span attributes and any
+// failure on the span.
+package otel
+
+import (
+	"context"
+	"time"
+
+	breakermux "github.com/cognusion/go-breakermux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/sony/gobreaker/v2"
+)
+
+// WrapExecClosure wraps execClosure so that every call it produces runs
+// inside its own span named spanName, as a child of ctx. The 'breaker key
+// and mux.State(key) (observed just before the call) are recorded as span
+// attributes, and a non-nil error is recorded on the span.
+//
+// execClosure only runs when the 'breaker actually attempts the call: a call
+// the 'breaker itself short-circuits (open, or too many requests while
+// half-open) never reaches execClosure at all, so no span is produced for
+// it. Use TracingObserver as the mux's Settings[T].Observer instead, or
+// alongside this, to also get a span for short-circuited calls.
+//
+// ExecFunc has no way to carry a per-call context through the mux, so every
+// span is parented to the same ctx passed at wrap time; callers that need
+// per-request parent spans should start one of their own and link to it out
+// of band (e.g. via the span's trace ID in a log line).
+func WrapExecClosure[T any](ctx context.Context, tracer trace.Tracer, spanName string, mux *breakermux.CircuitBreakerMux[T], execClosure func(string) func() (T, error)) func(string) func() (T, error) {
+	return func(key string) func() (T, error) {
+		inner := execClosure(key)
+
+		return func() (T, error) {
+			_, span := tracer.Start(ctx, spanName)
+			defer span.End()
+
+			span.SetAttributes(attribute.String("breakermux.key", key))
+			if state, ok := mux.State(key); ok {
+				span.SetAttributes(attribute.String("breakermux.state", state.String()))
+			}
+
+			value, err := inner()
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return value, err
+		}
+	}
+}
+
+// TracingObserver is a breakermux.Observer that records every request as a
+// span, including ones the 'breaker itself short-circuits -- the gap
+// WrapExecClosure can't cover, since execClosure never runs for those.
+// Because Observer is only notified after a request completes, each span is
+// reconstructed with its observed start time and duration (via
+// trace.WithTimestamp) rather than being live for the call's duration.
+type TracingObserver struct {
+	ctx      context.Context
+	tracer   trace.Tracer
+	spanName string
+}
+
+// NewTracingObserver returns a TracingObserver that starts every span as a
+// child of ctx, using tracer and spanName.
+func NewTracingObserver(ctx context.Context, tracer trace.Tracer, spanName string) *TracingObserver {
+	return &TracingObserver{ctx: ctx, tracer: tracer, spanName: spanName}
+}
+
+// OnRequest implements breakermux.Observer.
+func (o *TracingObserver) OnRequest(key string, success, shortCircuited bool, duration time.Duration) {
+	end := time.Now()
+	_, span := o.tracer.Start(o.ctx, o.spanName, trace.WithTimestamp(end.Add(-duration)))
+
+	span.SetAttributes(
+		attribute.String("breakermux.key", key),
+		attribute.Bool("breakermux.short_circuited", shortCircuited),
+	)
+	if !success {
+		if shortCircuited {
+			span.SetStatus(codes.Error, "circuit breaker open")
+		} else {
+			span.SetStatus(codes.Error, "request failed")
+		}
+	}
+
+	span.End(trace.WithTimestamp(end))
+}
+
+// OnStateChange implements breakermux.Observer.
+func (o *TracingObserver) OnStateChange(key string, from, to gobreaker.State) {
+	_, span := o.tracer.Start(o.ctx, o.spanName+".state_change")
+	span.SetAttributes(
+		attribute.String("breakermux.key", key),
+		attribute.String("breakermux.from", from.String()),
+		attribute.String("breakermux.to", to.String()),
+	)
+	span.End()
+}
+
+var _ breakermux.Observer = (*TracingObserver)(nil)
@@ -0,0 +1,109 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	breakermux "github.com/cognusion/go-breakermux"
+	"github.com/sony/gobreaker/v2"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWrapExecClosureRunsInnerAndReturnsItsResult(t *testing.T) {
+	Convey("Given an ExecClosure wrapped with a no-op tracer", t, func() {
+		var called bool
+		inner := func(key string) func() (int, error) {
+			return func() (int, error) {
+				called = true
+				if key == "fail" {
+					return 0, errors.New("boom")
+				}
+				return 1, nil
+			}
+		}
+
+		st := breakermux.Settings[int]{}
+		mux := breakermux.NewMux(st)
+		defer mux.Close()
+
+		var tracer trace.Tracer = noop.NewTracerProvider().Tracer("test")
+		wrapped := WrapExecClosure(context.Background(), tracer, "test.span", mux, inner)
+
+		Convey("A successful call runs the wrapped exec and returns its value", func() {
+			value, err := wrapped("ok")()
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, 1)
+			So(called, ShouldBeTrue)
+		})
+
+		Convey("A failing call returns the wrapped exec's error unchanged", func() {
+			_, err := wrapped("fail")()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+// noRemoteStore is a breakermux.StateStore that never reports a key as
+// remotely open, so a short-circuited call in these tests is always the
+// local gobreaker.CircuitBreaker rejecting it (exercising TracingObserver's
+// shortCircuited=true path), rather than CircuitBreakerMux's own
+// remotelyOpen gate short-circuiting before the 'breaker is ever consulted.
+type noRemoteStore struct{}
+
+func (noRemoteStore) Load(string) (breakermux.StoredState, bool, error) {
+	return breakermux.StoredState{}, false, nil
+}
+func (noRemoteStore) Save(string, breakermux.StoredState) error { return nil }
+func (noRemoteStore) CAS(string, breakermux.StoredState, breakermux.StoredState) (bool, error) {
+	return true, nil
+}
+
+func TestTracingObserverRecordsShortCircuitedRequests(t *testing.T) {
+	Convey("Given a mux whose Observer is a TracingObserver", t, func() {
+		exporter := tracetest.NewInMemoryExporter()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		tracer := tp.Tracer("test")
+
+		st := breakermux.Settings[int]{}
+		st.Timeout = time.Hour
+		st.ReadyToTrip = func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= 1 }
+		st.StateStore = noRemoteStore{}
+		st.Observer = NewTracingObserver(context.Background(), tracer, "test.span")
+		st.ExecClosure = func(key string) func() (int, error) {
+			return func() (int, error) { return 0, errors.New("boom") }
+		}
+
+		cbm := breakermux.NewMux(st)
+		defer cbm.Close()
+
+		// First call fails and trips the 'breaker open.
+		_, err := cbm.Get("svc")
+		So(err, ShouldNotBeNil)
+
+		Convey("A subsequent short-circuited call still produces a span, tagged accordingly", func() {
+			_, err := cbm.Get("svc")
+			So(err, ShouldEqual, gobreaker.ErrOpenState)
+
+			spans := exporter.GetSpans()
+			So(len(spans), ShouldBeGreaterThanOrEqualTo, 2)
+
+			last := spans[len(spans)-1]
+			So(last.Name, ShouldEqual, "test.span")
+
+			var shortCircuited bool
+			for _, attr := range last.Attributes {
+				if string(attr.Key) == "breakermux.short_circuited" {
+					shortCircuited = attr.Value.AsBool()
+				}
+			}
+			So(shortCircuited, ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package breakermux
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+)
+
+// RetryPolicy configures GetWithRetry and GetKeyExecWithRetry's backoff loop
+// above a mux's 'breaker.
+//
+// MaxAttempts is the maximum number of times the ExecFunc is called for a
+// single GetWithRetry/GetKeyExecWithRetry call. If MaxAttempts is less than
+// or equal to 0, it defaults to 1 (no retries).
+//
+// BaseDelay and MaxDelay bound the backoff between attempts: the delay
+// before retry n (0-indexed, so the first retry is n=0 and waits up to
+// BaseDelay) is min(MaxDelay, BaseDelay * 2^n). If Jitter is true, full
+// jitter is applied (a uniformly random delay between 0 and that value);
+// otherwise the delay is exactly that value. If
+// MaxDelay is less than or equal to 0, the doubling is left uncapped for the
+// life of the call.
+//
+// RetryableFunc decides whether an error from a failed attempt should be
+// retried. If RetryableFunc is nil, every error is considered retryable
+// except the 'breaker's own short-circuit errors (ErrOpenState and
+// ErrTooManyRequests), which are never retried since they mean the 'breaker
+// itself has already rejected the call.
+type RetryPolicy struct {
+	MaxAttempts   int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	Jitter        bool
+	RetryableFunc func(error) bool
+}
+
+// maxAttempts returns the effective attempt count, defaulting to 1.
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// delay returns the backoff to sleep before the given 0-indexed retry (retry
+// 0 precedes the second attempt), with full jitter applied unless Jitter is
+// false.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	if !p.Jitter {
+		return d
+	}
+	return time.Duration(rand.Int64N(int64(d)))
+}
+
+// retryable reports whether err should be retried: short-circuit errors
+// never are, and everything else defers to RetryableFunc, if set.
+func (p *RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return false
+	}
+	if p.RetryableFunc != nil {
+		return p.RetryableFunc(err)
+	}
+	return true
+}
+
+// noRetry is used when Settings[T].RetryPolicy is nil, so GetWithRetry and
+// GetKeyExecWithRetry degrade to a single attempt instead of needing a nil
+// check at every call site.
+var noRetry = &RetryPolicy{MaxAttempts: 1}
+
+// GetWithRetry behaves like Get, but retries the ExecFunc per the mux's
+// configured RetryPolicy while the 'breaker remains closed or half-open,
+// applying exponential backoff (jittered if the policy's Jitter is set)
+// between attempts. It stops and
+// returns immediately on success, on an error the policy deems
+// non-retryable, or the moment the 'breaker short-circuits a call. If no
+// RetryPolicy was configured, this is equivalent to Get.
+func (c *CircuitBreakerMux[T]) GetWithRetry(key string) (value T, err error) {
+	policy := c.retryPolicy
+	if policy == nil {
+		policy = noRetry
+	}
+
+	for attempt := 0; attempt < policy.maxAttempts(); attempt++ {
+		if attempt > 0 {
+			time.Sleep(policy.delay(attempt - 1))
+		}
+
+		value, err = c.Get(key)
+		if err == nil || !policy.retryable(err) {
+			return value, err
+		}
+	}
+
+	return value, err
+}
+
+// GetKeyExecWithRetry behaves like GetKeyExec, but retries exec per the
+// mux's configured RetryPolicy the same way GetWithRetry retries Get.
+func (c *CircuitBreakerMux[T]) GetKeyExecWithRetry(key, exec string) (value T, err error) {
+	policy := c.retryPolicy
+	if policy == nil {
+		policy = noRetry
+	}
+
+	for attempt := 0; attempt < policy.maxAttempts(); attempt++ {
+		if attempt > 0 {
+			time.Sleep(policy.delay(attempt - 1))
+		}
+
+		value, err = c.GetKeyExec(key, exec)
+		if err == nil || !policy.retryable(err) {
+			return value, err
+		}
+	}
+
+	return value, err
+}
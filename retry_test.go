@@ -0,0 +1,68 @@
+package breakermux
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetrySucceedsAfterTransientFailures(t *testing.T) {
+	Convey("Given a mux whose ExecFunc fails twice then succeeds, with a RetryPolicy allowing 3 attempts", t, func() {
+		var calls int
+		st := Settings[string]{}
+		st.Timeout = time.Hour
+		st.RetryPolicy = &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+		st.ExecClosure = func(key string) func() (string, error) {
+			return func() (string, error) {
+				calls++
+				if calls < 3 {
+					return "", errors.New("transient")
+				}
+				return "ok", nil
+			}
+		}
+
+		cbm := NewMux(st)
+		defer cbm.Close()
+
+		Convey("GetWithRetry retries until it succeeds", func() {
+			value, err := cbm.GetWithRetry("key")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "ok")
+			So(calls, ShouldEqual, 3)
+		})
+	})
+}
+
+func TestRetryStopsOnShortCircuit(t *testing.T) {
+	Convey("Given a mux whose 'breaker is already open", t, func() {
+		var calls int
+		st := Settings[string]{}
+		st.Timeout = time.Hour
+		st.ReadyToTrip = func(counts gobreaker.Counts) bool { return true }
+		st.RetryPolicy = &RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+		st.ExecClosure = func(key string) func() (string, error) {
+			return func() (string, error) {
+				calls++
+				return "", errors.New("fail")
+			}
+		}
+
+		cbm := NewMux(st)
+		defer cbm.Close()
+
+		// Trip the 'breaker.
+		_, _ = cbm.Get("key")
+
+		Convey("GetWithRetry does not retry the resulting ErrOpenState", func() {
+			calls = 0
+			_, err := cbm.GetWithRetry("key")
+			So(errors.Is(err, gobreaker.ErrOpenState), ShouldBeTrue)
+			So(calls, ShouldEqual, 0)
+		})
+	})
+}
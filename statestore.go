@@ -0,0 +1,89 @@
+package breakermux
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker/v2"
+)
+
+// StoredState is the durable representation of a single key's circuit breaker
+// state, as persisted by a StateStore. It captures just enough of gobreaker's
+// internal bookkeeping to let a freshly-created 'breaker in another process
+// recognize that a key is already open, without needing access to gobreaker's
+// unexported fields. It deliberately carries no Counts: gobreaker calls
+// OnStateChange (which publishes this) with its internal mutex held and
+// Counts already cleared for the new generation, so there is no safe way to
+// capture a meaningful value (see publishState).
+type StoredState struct {
+	State    gobreaker.State
+	OpenedAt time.Time
+}
+
+// StateStore allows a CircuitBreakerMux's per-key state to be shared across
+// a fleet of processes, so they observe the same open/half-open/closed state
+// for a given key instead of each tripping independently.
+//
+// Load returns the currently stored state for key, and false if none exists.
+//
+// Save unconditionally overwrites the stored state for key.
+//
+// CAS stores new only if the value currently stored for key equals old (or
+// nothing is stored and old is the zero StoredState), returning false if
+// another process already won the race to update key. CircuitBreakerMux
+// calls this, not Save, to publish its own transitions, so two processes
+// racing to update the same key serialize against one another instead of
+// one clobbering the other.
+type StateStore interface {
+	Load(key string) (StoredState, bool, error)
+	Save(key string, state StoredState) error
+	CAS(key string, old, new StoredState) (bool, error)
+}
+
+// memoryStateStore is the zero-config StateStore used when Settings[T].StateStore
+// is left nil. It preserves the pre-existing behavior of this package: state
+// lives only in the local process and is never shared with peers.
+type memoryStateStore struct {
+	m sync.Map
+}
+
+func newMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{}
+}
+
+func (s *memoryStateStore) Load(key string) (StoredState, bool, error) {
+	v, ok := s.m.Load(key)
+	if !ok {
+		return StoredState{}, false, nil
+	}
+	return v.(StoredState), true, nil
+}
+
+func (s *memoryStateStore) Save(key string, state StoredState) error {
+	s.m.Store(key, state)
+	return nil
+}
+
+func (s *memoryStateStore) CAS(key string, old, new StoredState) (bool, error) {
+	for {
+		var cur StoredState
+		v, loaded := s.m.Load(key)
+		if loaded {
+			cur = v.(StoredState)
+		}
+		if cur != old {
+			return false, nil
+		}
+
+		if !loaded {
+			if _, already := s.m.LoadOrStore(key, new); !already {
+				return true, nil
+			}
+			continue // another goroutine raced us in; re-check its value
+		}
+		if s.m.CompareAndSwap(key, old, new) {
+			return true, nil
+		}
+		// Someone else raced us between the Load and the CompareAndSwap; retry.
+	}
+}
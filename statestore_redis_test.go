@@ -0,0 +1,63 @@
+package breakermux
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sony/gobreaker/v2"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestRedisStateStore(t *testing.T) *RedisStateStore {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisStateStore(client, "test:", 0)
+}
+
+func TestRedisStateStore(t *testing.T) {
+	Convey("When a RedisStateStore is empty, Load reports no value for any key.", t, func() {
+		s := newTestRedisStateStore(t)
+
+		_, ok, err := s.Load("nope")
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeFalse)
+
+		Convey("... and Save followed by Load round-trips the value.", func() {
+			want := StoredState{State: gobreaker.StateOpen, OpenedAt: time.Now().Truncate(time.Second)}
+			So(s.Save("key", want), ShouldBeNil)
+
+			got, ok, err := s.Load("key")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(got.State, ShouldEqual, want.State)
+			So(got.OpenedAt.Equal(want.OpenedAt), ShouldBeTrue)
+		})
+
+		Convey("... and CAS against an unset key only succeeds when old is the zero value.", func() {
+			ok, err := s.CAS("key", StoredState{State: gobreaker.StateOpen}, StoredState{State: gobreaker.StateClosed})
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			ok, err = s.CAS("key", StoredState{}, StoredState{State: gobreaker.StateOpen})
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			got, _, _ := s.Load("key")
+			So(got.State, ShouldEqual, gobreaker.StateOpen)
+
+			Convey("... and a subsequent CAS against the wrong old value fails without overwriting.", func() {
+				ok, err := s.CAS("key", StoredState{State: gobreaker.StateClosed}, StoredState{State: gobreaker.StateHalfOpen})
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeFalse)
+
+				got, _, _ := s.Load("key")
+				So(got.State, ShouldEqual, gobreaker.StateOpen)
+			})
+		})
+	})
+}
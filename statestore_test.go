@@ -0,0 +1,122 @@
+package breakermux
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/sony/gobreaker/v2"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	Convey("When a memoryStateStore is empty, Load reports no value for any key.", t, func() {
+		s := newMemoryStateStore()
+
+		_, ok, err := s.Load("nope")
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeFalse)
+
+		Convey("... and Save followed by Load round-trips the value.", func() {
+			want := StoredState{State: gobreaker.StateOpen, OpenedAt: time.Now()}
+			So(s.Save("key", want), ShouldBeNil)
+
+			got, ok, err := s.Load("key")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(got, ShouldResemble, want)
+		})
+
+		Convey("... and CAS against an unset key only succeeds when old is the zero value.", func() {
+			ok, err := s.CAS("key", StoredState{State: gobreaker.StateOpen}, StoredState{State: gobreaker.StateClosed})
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			ok, err = s.CAS("key", StoredState{}, StoredState{State: gobreaker.StateOpen})
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			got, _, _ := s.Load("key")
+			So(got.State, ShouldEqual, gobreaker.StateOpen)
+		})
+	})
+}
+
+func TestMuxStateStorePublishesTransitions(t *testing.T) {
+	Convey("When a mux trips a 'breaker open, the configured StateStore observes the transition.", t, func() {
+		store := newMemoryStateStore()
+
+		st := Settings[string]{}
+		st.Timeout = time.Hour
+		st.StateStore = store
+		st.ExecClosure = func(input string) func() (string, error) {
+			return func() (string, error) {
+				return "", fmt.Errorf("always fails")
+			}
+		}
+
+		cbm := NewMux(st)
+		defer cbm.Close()
+
+		for range 10 {
+			cbm.Get("flaky")
+		}
+
+		// publishState runs off of OnStateChange's goroutine (see cbmux.go),
+		// so the write can land slightly after Get returns; poll for it
+		// instead of asserting immediately.
+		var stored StoredState
+		var ok bool
+		var err error
+		for i := 0; i < 100; i++ {
+			stored, ok, err = store.Load("flaky")
+			if ok {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(stored.State, ShouldEqual, gobreaker.StateOpen)
+
+		Convey("... and a second mux sharing the store honors the remote open state without its own failures.", func() {
+			st2 := Settings[string]{}
+			st2.Timeout = time.Hour
+			st2.StateStore = store
+			st2.ExecClosure = func(input string) func() (string, error) {
+				return func() (string, error) {
+					return "ok", nil
+				}
+			}
+
+			cbm2 := NewMux(st2)
+			defer cbm2.Close()
+
+			_, err := cbm2.Get("flaky")
+			So(err, ShouldEqual, gobreaker.ErrOpenState)
+		})
+	})
+}
+
+func TestPublishStateSerializesViaCAS(t *testing.T) {
+	Convey("Given a key whose stored state was overwritten by a peer after this mux last observed it", t, func() {
+		store := newMemoryStateStore()
+		So(store.Save("key", StoredState{State: gobreaker.StateClosed}), ShouldBeNil)
+
+		st := Settings[string]{}
+		st.StateStore = store
+		cbm := NewMux(st)
+		defer cbm.Close()
+
+		stale, _, _ := store.Load("key")
+		So(store.Save("key", StoredState{State: gobreaker.StateHalfOpen}), ShouldBeNil)
+
+		Convey("publishState's CAS loop re-reads the peer's write and CASes against it, so its own transition still lands", func() {
+			cbm.publishState("key", gobreaker.StateOpen)
+
+			got, _, _ := store.Load("key")
+			So(got.State, ShouldEqual, gobreaker.StateOpen)
+			So(got, ShouldNotResemble, stale)
+		})
+	})
+}